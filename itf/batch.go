@@ -0,0 +1,118 @@
+package itf
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mdzio/go-hmccu/model"
+)
+
+// BatchResult holds the outcome of a single call queued in a Batch. Either
+// Result or Err is set, mirroring the fault/success union the CCU returns
+// for each entry of a system.multicall response.
+type BatchResult struct {
+	Result interface{}
+	Err    error
+}
+
+type batchCall struct {
+	method string
+	params []interface{}
+}
+
+// Batch collects several RPC calls that are executed as a single
+// system.multicall request. This drastically reduces the number of HTTP
+// round trips when many values must be read or written at once.
+type Batch struct {
+	client *Client
+	calls  []batchCall
+}
+
+// NewBatch creates an empty Batch bound to c. Queue calls with SetValue,
+// GetValue and GetParamset, then send them with Execute.
+func (c *Client) NewBatch() *Batch {
+	return &Batch{client: c}
+}
+
+// SetValue queues a setValue call.
+func (b *Batch) SetValue(deviceAddress, valueName string, value interface{}) *Batch {
+	b.calls = append(b.calls, batchCall{"setValue", []interface{}{deviceAddress, valueName, value}})
+	return b
+}
+
+// GetValue queues a getValue call.
+func (b *Batch) GetValue(deviceAddress, valueName string) *Batch {
+	b.calls = append(b.calls, batchCall{"getValue", []interface{}{deviceAddress, valueName}})
+	return b
+}
+
+// GetParamset queues a getParamset call.
+func (b *Batch) GetParamset(deviceAddress, paramsetType string) *Batch {
+	b.calls = append(b.calls, batchCall{"getParamset", []interface{}{deviceAddress, paramsetType}})
+	return b
+}
+
+// PutParamset queues a putParamset call.
+func (b *Batch) PutParamset(deviceAddress, paramsetType string, paramset map[string]interface{}) *Batch {
+	b.calls = append(b.calls, batchCall{"putParamset", []interface{}{deviceAddress, paramsetType, paramset}})
+	return b
+}
+
+// Execute sends all queued calls as a single system.multicall request and
+// returns one BatchResult per queued call, in the order they were added.
+// On success the queue is emptied so the Batch can be reused; when an error
+// is returned the queued calls are kept.
+func (b *Batch) Execute(ctx context.Context) ([]BatchResult, error) {
+	if len(b.calls) == 0 {
+		return nil, nil
+	}
+	var entries []interface{}
+	for _, c := range b.calls {
+		entries = append(entries, map[string]interface{}{
+			"methodName": c.method,
+			"params":     c.params,
+		})
+	}
+	v, err := model.NewValue(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	clnLog.Debugf("Calling method system.multicall(%d calls) on %s", len(b.calls), b.client.Addr)
+	resp, err := b.client.CallCtx(ctx, "system.multicall", []*model.Value{v})
+	if err != nil {
+		return nil, err
+	}
+
+	e := model.Q(resp)
+	items := e.Slice()
+	if e.Err() != nil {
+		return nil, fmt.Errorf("Invalid XML response for system.multicall: %v", e.Err())
+	}
+	if len(items) != len(b.calls) {
+		return nil, fmt.Errorf("system.multicall: expected %d results, got %d", len(b.calls), len(items))
+	}
+
+	results := make([]BatchResult, len(items))
+	for i, item := range items {
+		raw := item.Any()
+		if item.Err() != nil {
+			results[i] = BatchResult{Err: fmt.Errorf("Invalid multicall result at index %d: %v", i, item.Err())}
+			continue
+		}
+		switch r := raw.(type) {
+		case map[string]interface{}:
+			results[i] = BatchResult{Err: fmt.Errorf("fault %v: %v", r["faultCode"], r["faultString"])}
+		case []interface{}:
+			if len(r) != 1 {
+				results[i] = BatchResult{Err: fmt.Errorf("Invalid multicall result at index %d: expected 1 element, got %d", i, len(r))}
+				continue
+			}
+			results[i] = BatchResult{Result: r[0]}
+		default:
+			results[i] = BatchResult{Err: fmt.Errorf("Invalid multicall result at index %d: unexpected type %T", i, raw)}
+		}
+	}
+	b.calls = nil
+	return results, nil
+}
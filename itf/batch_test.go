@@ -0,0 +1,86 @@
+package itf
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mdzio/go-hmccu/model"
+)
+
+// fakeRPC is a minimal rpcClient that returns a canned system.multicall
+// response and records the call it received.
+type fakeRPC struct {
+	gotMethod string
+	gotParams []*model.Value
+	resp      *model.Value
+	err       error
+}
+
+func (f *fakeRPC) Call(method string, params []*model.Value) (*model.Value, error) {
+	f.gotMethod = method
+	f.gotParams = params
+	return f.resp, f.err
+}
+
+func TestBatchExecute(t *testing.T) {
+	// A system.multicall response is an array with one entry per call: a
+	// single-element array wrapping a success result, or a fault struct.
+	resp, err := model.NewValue([]interface{}{
+		[]interface{}{"ok"},
+		map[string]interface{}{"faultCode": -1, "faultString": "boom"},
+	})
+	if err != nil {
+		t.Fatalf("building fake response: %v", err)
+	}
+	fake := &fakeRPC{resp: resp}
+	c := &Client{Addr: "test", rpcClient: fake}
+
+	b := c.NewBatch()
+	b.SetValue("DEV1:1", "STATE", true)
+	b.GetValue("DEV1:1", "STATE")
+
+	results, err := b.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if fake.gotMethod != "system.multicall" {
+		t.Fatalf("expected system.multicall, got %s", fake.gotMethod)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Err != nil || results[0].Result != "ok" {
+		t.Errorf("unexpected result[0]: %+v", results[0])
+	}
+	if results[1].Err == nil {
+		t.Errorf("expected result[1] to be a fault error")
+	}
+	if len(b.calls) != 0 {
+		t.Errorf("expected Batch queue to be emptied after Execute, got %d", len(b.calls))
+	}
+}
+
+func TestBatchExecuteEmpty(t *testing.T) {
+	c := &Client{Addr: "test", rpcClient: &fakeRPC{}}
+	results, err := c.NewBatch().Execute(context.Background())
+	if err != nil || results != nil {
+		t.Fatalf("expected (nil, nil) for an empty batch, got (%v, %v)", results, err)
+	}
+}
+
+func TestBatchExecuteResultCountMismatch(t *testing.T) {
+	resp, err := model.NewValue([]interface{}{[]interface{}{"ok"}})
+	if err != nil {
+		t.Fatalf("building fake response: %v", err)
+	}
+	fake := &fakeRPC{resp: resp}
+	c := &Client{Addr: "test", rpcClient: fake}
+
+	b := c.NewBatch()
+	b.GetValue("DEV1:1", "STATE")
+	b.GetValue("DEV1:1", "STATE")
+
+	if _, err := b.Execute(context.Background()); err == nil {
+		t.Error("expected an error on result count mismatch")
+	}
+}
@@ -1,6 +1,8 @@
 package itf
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/mdzio/go-hmccu/binrpc"
@@ -18,26 +20,102 @@ type rpcClient interface {
 	Call(method string, params []*model.Value) (*model.Value, error)
 }
 
+// ctxRpcClient is implemented by an rpcClient that can abort an in-flight
+// call when its context is done (e.g. by closing the underlying socket or
+// cancelling the HTTP request). binrpc.Client and xmlrpc.Client are
+// expected to grow this as a follow-up; until then, Client.CallCtx falls
+// back to a plain blocking Call.
+type ctxRpcClient interface {
+	CallCtx(ctx context.Context, method string, params []*model.Value) (*model.Value, error)
+}
+
 // Client provides access to the HomeMatic XML-RPC API.
 type Client struct {
 	Addr string
 	rpcClient
 }
 
-// NewClient creates a new Client.
+// CallCtx calls method on the underlying transport, passing ctx through
+// when the transport implements ctxRpcClient so it can abort an in-flight
+// HTTP request or socket read as soon as ctx is done.
+//
+// Neither binrpc.Client nor xmlrpc.Client implement ctxRpcClient yet, so
+// for them cancellation is currently a no-op once the call is issued: ctx
+// is only checked before the (blocking) call starts, not while it is
+// waiting on the CCU. A stalled CCU still blocks the calling goroutine for
+// the full duration regardless of ctx. Callers relying on *Ctx methods to
+// bound a stalled call must wait for a transport to grow ctxRpcClient.
+func (c *Client) CallCtx(ctx context.Context, method string, params []*model.Value) (*model.Value, error) {
+	if cc, ok := c.rpcClient.(ctxRpcClient); ok {
+		return cc.CallCtx(ctx, method, params)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Call(method, params)
+}
+
+// tlsConfigurer is implemented by an rpcClient that can be told to dial
+// with a custom *tls.Config, e.g. for https:// or binrpcs:// addresses
+// that need CA pinning, a client certificate or a specific SNI name.
+// binrpc.Client and xmlrpc.Client are expected to grow this as a
+// follow-up; NewTLSClient errors out against a transport that doesn't
+// implement it yet instead of silently dropping tlsConfig.
+type tlsConfigurer interface {
+	SetTLSConfig(*tls.Config)
+}
+
+// NewClient creates a new Client. The address scheme selects the
+// transport: http:// and https:// use xmlrpc, everything else (including
+// binrpc:// and binrpcs://) uses binrpc.
 func NewClient(address string) *Client {
-	if strings.HasPrefix(address, "http://") {
-		return &Client{address, &xmlrpc.Client{Addr: address}}
+	// tlsConfig is nil, so newClient never returns an error here.
+	c, _ := newClient(address, nil)
+	return c
+}
+
+// NewTLSClient creates a new Client like NewClient, additionally applying
+// tlsConfig to the chosen transport. It returns an error if tlsConfig is
+// non-nil and the transport selected for address does not yet implement
+// tlsConfigurer, rather than silently ignoring it.
+func NewTLSClient(address string, tlsConfig *tls.Config) (*Client, error) {
+	return newClient(address, tlsConfig)
+}
+
+func newClient(address string, tlsConfig *tls.Config) (*Client, error) {
+	var rc rpcClient
+	if strings.HasPrefix(address, "http://") || strings.HasPrefix(address, "https://") {
+		rc = &xmlrpc.Client{Addr: address}
+	} else {
+		// binrpc is a raw TCP protocol, not URL-based: binrpc.Client expects
+		// a bare host:port, so the binrpc(s):// scheme (unlike http(s)://)
+		// must be stripped before it is passed through.
+		addr := strings.TrimPrefix(strings.TrimPrefix(address, "binrpcs://"), "binrpc://")
+		rc = &binrpc.Client{Addr: addr}
+	}
+	if tlsConfig != nil {
+		tc, ok := rc.(tlsConfigurer)
+		if !ok {
+			return nil, fmt.Errorf("itf: %T does not support a custom TLS configuration yet", rc)
+		}
+		tc.SetTLSConfig(tlsConfig)
 	}
-	return &Client{address, &binrpc.Client{Addr: address}}
+	return &Client{address, rc}, nil
 }
 
 // GetDeviceDescription retrieves the device description for the specified
 // device.
 func (c *Client) GetDeviceDescription(deviceAddress string) (*DeviceDescription, error) {
+	return c.GetDeviceDescriptionCtx(context.Background(), deviceAddress)
+}
+
+// GetDeviceDescriptionCtx retrieves the device description for the specified
+// device. ctx can abort the call in flight only on a transport implementing
+// ctxRpcClient; see CallCtx.
+func (c *Client) GetDeviceDescriptionCtx(ctx context.Context, deviceAddress string) (*DeviceDescription, error) {
 	clnLog.Debugf("Calling method getDeviceDescription(%s) on %s", deviceAddress, c.Addr)
 	// execute call
-	v, err := c.Call("getDeviceDescription", []*model.Value{
+	v, err := c.CallCtx(ctx, "getDeviceDescription", []*model.Value{
 		&model.Value{FlatString: deviceAddress},
 	})
 	if err != nil {
@@ -56,9 +134,16 @@ func (c *Client) GetDeviceDescription(deviceAddress string) (*DeviceDescription,
 
 // ListDevices retrieves the device descriptions from all devices.
 func (c *Client) ListDevices() ([]*DeviceDescription, error) {
+	return c.ListDevicesCtx(context.Background())
+}
+
+// ListDevicesCtx retrieves the device descriptions from all devices. ctx can
+// abort the call in flight only on a transport implementing ctxRpcClient;
+// see CallCtx.
+func (c *Client) ListDevicesCtx(ctx context.Context) ([]*DeviceDescription, error) {
 	clnLog.Debugf("Calling method listDevices on %s", c.Addr)
 	// execute call
-	v, err := c.Call("listDevices", []*model.Value{})
+	v, err := c.CallCtx(ctx, "listDevices", []*model.Value{})
 	if err != nil {
 		return nil, err
 	}
@@ -80,9 +165,16 @@ func (c *Client) ListDevices() ([]*DeviceDescription, error) {
 
 // GetParamsetDescription retrieves the paramset description from a device.
 func (c *Client) GetParamsetDescription(deviceAddress string, paramsetType string) (ParamsetDescription, error) {
+	return c.GetParamsetDescriptionCtx(context.Background(), deviceAddress, paramsetType)
+}
+
+// GetParamsetDescriptionCtx retrieves the paramset description from a
+// device. ctx can abort the call in flight only on a transport implementing
+// ctxRpcClient; see CallCtx.
+func (c *Client) GetParamsetDescriptionCtx(ctx context.Context, deviceAddress string, paramsetType string) (ParamsetDescription, error) {
 	clnLog.Debugf("Calling method getParamsetDescription(%s, %s) on %s", deviceAddress, paramsetType, c.Addr)
 	// execute call
-	v, err := c.Call("getParamsetDescription", []*model.Value{
+	v, err := c.CallCtx(ctx, "getParamsetDescription", []*model.Value{
 		&model.Value{FlatString: deviceAddress},
 		&model.Value{FlatString: paramsetType},
 	})
@@ -109,9 +201,15 @@ func (c *Client) GetParamsetDescription(deviceAddress string, paramsetType strin
 
 // GetParamset retrieves the specified parameter set.
 func (c *Client) GetParamset(deviceAddress string, paramsetType string) (map[string]interface{}, error) {
+	return c.GetParamsetCtx(context.Background(), deviceAddress, paramsetType)
+}
+
+// GetParamsetCtx retrieves the specified parameter set. ctx can abort the
+// call in flight only on a transport implementing ctxRpcClient; see CallCtx.
+func (c *Client) GetParamsetCtx(ctx context.Context, deviceAddress string, paramsetType string) (map[string]interface{}, error) {
 	clnLog.Debugf("Calling method getParamset(%s, %s) on %s", deviceAddress, paramsetType, c.Addr)
 	// execute call
-	v, err := c.Call("getParamset", []*model.Value{
+	v, err := c.CallCtx(ctx, "getParamset", []*model.Value{
 		&model.Value{FlatString: deviceAddress},
 		&model.Value{FlatString: paramsetType},
 	})
@@ -137,6 +235,12 @@ func (c *Client) GetParamset(deviceAddress string, paramsetType string) (map[str
 
 // PutParamset writes the parameter set.
 func (c *Client) PutParamset(deviceAddress string, paramsetType string, paramset map[string]interface{}) error {
+	return c.PutParamsetCtx(context.Background(), deviceAddress, paramsetType, paramset)
+}
+
+// PutParamsetCtx writes the parameter set. ctx can abort the call in flight
+// only on a transport implementing ctxRpcClient; see CallCtx.
+func (c *Client) PutParamsetCtx(ctx context.Context, deviceAddress string, paramsetType string, paramset map[string]interface{}) error {
 	clnLog.Debugf("Calling method putParamset(%s, %s) on %s", deviceAddress, paramsetType, c.Addr)
 	// convert value
 	ps, err := model.NewValue(paramset)
@@ -144,7 +248,7 @@ func (c *Client) PutParamset(deviceAddress string, paramsetType string, paramset
 		return err
 	}
 	// execute call
-	resp, err := c.Call("putParamset", []*model.Value{
+	resp, err := c.CallCtx(ctx, "putParamset", []*model.Value{
 		&model.Value{FlatString: deviceAddress},
 		&model.Value{FlatString: paramsetType},
 		ps,
@@ -171,6 +275,13 @@ func (c *Client) assertEmptyResponse(v *model.Value) error {
 
 // SetValue sets a single value from the parameter set VALUES.
 func (c *Client) SetValue(deviceAddress string, valueName string, value interface{}) error {
+	return c.SetValueCtx(context.Background(), deviceAddress, valueName, value)
+}
+
+// SetValueCtx sets a single value from the parameter set VALUES. ctx can
+// abort the call in flight only on a transport implementing ctxRpcClient;
+// see CallCtx.
+func (c *Client) SetValueCtx(ctx context.Context, deviceAddress string, valueName string, value interface{}) error {
 	clnLog.Debugf("Calling method setValue(%s, %s, %v) on %s", deviceAddress, valueName, value, c.Addr)
 	// convert value
 	v, err := model.NewValue(value)
@@ -178,7 +289,7 @@ func (c *Client) SetValue(deviceAddress string, valueName string, value interfac
 		return err
 	}
 	// execute call
-	resp, err := c.Call("setValue", []*model.Value{
+	resp, err := c.CallCtx(ctx, "setValue", []*model.Value{
 		&model.Value{FlatString: deviceAddress},
 		&model.Value{FlatString: valueName},
 		v,
@@ -196,9 +307,16 @@ func (c *Client) SetValue(deviceAddress string, valueName string, value interfac
 
 // GetValue gets a single value from the parameter set VALUES.
 func (c *Client) GetValue(deviceAddress string, valueName string) (interface{}, error) {
+	return c.GetValueCtx(context.Background(), deviceAddress, valueName)
+}
+
+// GetValueCtx gets a single value from the parameter set VALUES. ctx can
+// abort the call in flight only on a transport implementing ctxRpcClient;
+// see CallCtx.
+func (c *Client) GetValueCtx(ctx context.Context, deviceAddress string, valueName string) (interface{}, error) {
 	clnLog.Debugf("Calling method getValue(%s, %s) on %s", deviceAddress, valueName, c.Addr)
 	// execute call
-	resp, err := c.Call("getValue", []*model.Value{
+	resp, err := c.CallCtx(ctx, "getValue", []*model.Value{
 		&model.Value{FlatString: deviceAddress},
 		&model.Value{FlatString: valueName},
 	})
@@ -218,9 +336,16 @@ func (c *Client) GetValue(deviceAddress string, valueName string) (interface{},
 // http://hostname[:port][/Path]. If the path is not specified, the CCU will use
 // /RPC2.
 func (c *Client) Init(receiverAddress, id string) error {
+	return c.InitCtx(context.Background(), receiverAddress, id)
+}
+
+// InitCtx registers a new interface. ctx can abort the call in flight only
+// on a transport implementing ctxRpcClient; see CallCtx.
+// See Init for details on receiverAddress.
+func (c *Client) InitCtx(ctx context.Context, receiverAddress, id string) error {
 	clnLog.Debugf("Calling method init(%s, %s) on %s", receiverAddress, id, c.Addr)
 	// execute call
-	resp, err := c.Call("init", []*model.Value{
+	resp, err := c.CallCtx(ctx, "init", []*model.Value{
 		&model.Value{FlatString: receiverAddress},
 		&model.Value{FlatString: id},
 	})
@@ -237,9 +362,15 @@ func (c *Client) Init(receiverAddress, id string) error {
 
 // Deinit deregisters an interface. The receiverAddress should match with Init.
 func (c *Client) Deinit(receiverAddress string) error {
+	return c.DeinitCtx(context.Background(), receiverAddress)
+}
+
+// DeinitCtx deregisters an interface. ctx can abort the call in flight only
+// on a transport implementing ctxRpcClient; see CallCtx.
+func (c *Client) DeinitCtx(ctx context.Context, receiverAddress string) error {
 	clnLog.Debugf("Calling method init(%s) on %s", receiverAddress, c.Addr)
 	// execute call
-	resp, err := c.Call("init", []*model.Value{
+	resp, err := c.CallCtx(ctx, "init", []*model.Value{
 		&model.Value{FlatString: receiverAddress},
 		// omit 2nd parameter
 	})
@@ -256,9 +387,15 @@ func (c *Client) Deinit(receiverAddress string) error {
 
 // Ping triggers a pong event. Returns true on success.
 func (c *Client) Ping(callerID string) (bool, error) {
+	return c.PingCtx(context.Background(), callerID)
+}
+
+// PingCtx triggers a pong event. ctx can abort the call in flight only on a
+// transport implementing ctxRpcClient; see CallCtx. Returns true on success.
+func (c *Client) PingCtx(ctx context.Context, callerID string) (bool, error) {
 	clnLog.Debugf("Calling method ping(%s) on %s", callerID, c.Addr)
 	// execute call
-	resp, err := c.Call("ping", []*model.Value{
+	resp, err := c.CallCtx(ctx, "ping", []*model.Value{
 		&model.Value{FlatString: callerID},
 	})
 	if err != nil {
@@ -280,13 +417,19 @@ func (c *Client) Ping(callerID string) (bool, error) {
 
 // Event sends an event.
 func (c *Client) Event(interfaceID, address, valueKey string, value interface{}) error {
+	return c.EventCtx(context.Background(), interfaceID, address, valueKey, value)
+}
+
+// EventCtx sends an event. ctx can abort the call in flight only on a
+// transport implementing ctxRpcClient; see CallCtx.
+func (c *Client) EventCtx(ctx context.Context, interfaceID, address, valueKey string, value interface{}) error {
 	clnLog.Debugf("Calling method event(%s, %s, %s, %v) on %s", interfaceID, address, valueKey, value, c.Addr)
 	// execute call
 	v, err := model.NewValue(value)
 	if err != nil {
 		return err
 	}
-	resp, err := c.Call("event", []*model.Value{
+	resp, err := c.CallCtx(ctx, "event", []*model.Value{
 		&model.Value{FlatString: interfaceID},
 		&model.Value{FlatString: address},
 		&model.Value{FlatString: valueKey},
@@ -0,0 +1,69 @@
+package itf
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mdzio/go-hmccu/model"
+)
+
+// ctxAwareRPC implements ctxRpcClient in addition to rpcClient, to verify
+// CallCtx prefers it over the blocking Call fallback.
+type ctxAwareRPC struct {
+	sawCtxCall bool
+	sawCall    bool
+	resp       *model.Value
+	err        error
+}
+
+func (r *ctxAwareRPC) Call(method string, params []*model.Value) (*model.Value, error) {
+	r.sawCall = true
+	return r.resp, r.err
+}
+
+func (r *ctxAwareRPC) CallCtx(ctx context.Context, method string, params []*model.Value) (*model.Value, error) {
+	r.sawCtxCall = true
+	return r.resp, r.err
+}
+
+func TestClientCallCtxPrefersCtxRpcClient(t *testing.T) {
+	rc := &ctxAwareRPC{resp: &model.Value{FlatString: "ok"}}
+	c := &Client{Addr: "test", rpcClient: rc}
+
+	if _, err := c.CallCtx(context.Background(), "ping", nil); err != nil {
+		t.Fatalf("CallCtx returned error: %v", err)
+	}
+	if !rc.sawCtxCall {
+		t.Error("expected CallCtx to be routed to CallCtx on a ctxRpcClient")
+	}
+	if rc.sawCall {
+		t.Error("did not expect the blocking Call fallback to be used")
+	}
+}
+
+func TestClientCallCtxFallsBackToCallWithoutCtxRpcClient(t *testing.T) {
+	rc := &fakeRPC{resp: &model.Value{FlatString: "ok"}}
+	c := &Client{Addr: "test", rpcClient: rc}
+
+	if _, err := c.CallCtx(context.Background(), "ping", nil); err != nil {
+		t.Fatalf("CallCtx returned error: %v", err)
+	}
+	if rc.gotMethod != "ping" {
+		t.Error("expected CallCtx to fall back to the blocking Call")
+	}
+}
+
+func TestClientCallCtxRejectsAlreadyCanceledContextWithoutCtxRpcClient(t *testing.T) {
+	rc := &fakeRPC{resp: &model.Value{FlatString: "ok"}}
+	c := &Client{Addr: "test", rpcClient: rc}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.CallCtx(ctx, "ping", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if rc.gotMethod != "" {
+		t.Error("expected the already-canceled context to be checked before issuing the blocking Call")
+	}
+}
@@ -0,0 +1,268 @@
+package itf
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mdzio/go-logging"
+)
+
+var haLog = logging.Get("itf-ha")
+
+// PrimaryPolicy decides which peer of a HAClient is currently allowed to
+// serve state-changing calls.
+type PrimaryPolicy int
+
+const (
+	// FirstReachable designates the first peer (in the order passed to
+	// NewHAClient) that answered the last Ping as primary.
+	FirstReachable PrimaryPolicy = iota
+
+	// Sticky keeps the current primary as long as it stays reachable, and
+	// only fails over to the next reachable peer when it goes down. This
+	// avoids flapping back to a peer right after it recovers.
+	Sticky
+
+	// External defers the decision to HAClient.PrimaryFunc.
+	External
+)
+
+// HAClient fronts several CCU interface processes (e.g. a primary and a hot
+// standby) with a single Client-like API. Idempotent calls are retried
+// against the next reachable peer; state-changing calls are routed only to
+// the peer currently designated as primary.
+type HAClient struct {
+	// Peers are tried in this order for FirstReachable and as the failover
+	// sequence for Sticky.
+	Peers []*Client
+
+	// Policy selects how the primary peer is determined. Defaults to
+	// FirstReachable.
+	Policy PrimaryPolicy
+
+	// PrimaryFunc is consulted when Policy is External. It must return one
+	// of the Peers.
+	PrimaryFunc func() *Client
+
+	mu         sync.RWMutex
+	reachable  []bool
+	stickyIdx  int
+	cancelPoll context.CancelFunc
+}
+
+// NewHAClient creates a HAClient fronting peers. peers must not be empty;
+// only the first entry is treated as reachable until the first poll
+// completes, so a configured-but-actually-down standby is never used as a
+// failover target before StartPolling has verified it.
+func NewHAClient(peers ...*Client) *HAClient {
+	reachable := make([]bool, len(peers))
+	if len(reachable) > 0 {
+		reachable[0] = true
+	}
+	return &HAClient{
+		Peers:     peers,
+		reachable: reachable,
+	}
+}
+
+// StartPolling periodically Pings every peer to refresh reachability until
+// ctx is done. callerID is passed through to Ping.
+func (h *HAClient) StartPolling(ctx context.Context, interval time.Duration, callerID string) {
+	ctx, cancel := context.WithCancel(ctx)
+	h.cancelPoll = cancel
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		h.poll(ctx, callerID)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.poll(ctx, callerID)
+			}
+		}
+	}()
+}
+
+// StopPolling stops a polling loop started with StartPolling.
+func (h *HAClient) StopPolling() {
+	if h.cancelPoll != nil {
+		h.cancelPoll()
+	}
+}
+
+func (h *HAClient) poll(ctx context.Context, callerID string) {
+	for i, p := range h.Peers {
+		ok, err := p.PingCtx(ctx, callerID)
+		reachable := err == nil && ok
+		h.mu.Lock()
+		if h.reachable[i] != reachable {
+			haLog.Infof("Peer %s reachability changed: %v", p.Addr, reachable)
+		}
+		h.reachable[i] = reachable
+		h.mu.Unlock()
+	}
+}
+
+// isReachable reports the last known reachability of peer i.
+func (h *HAClient) isReachable(i int) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.reachable[i]
+}
+
+// primary returns the peer currently designated as primary according to
+// Policy.
+func (h *HAClient) primary() (*Client, error) {
+	switch h.Policy {
+	case External:
+		if h.PrimaryFunc == nil {
+			return nil, fmt.Errorf("itf: HAClient.Policy is External but PrimaryFunc is nil")
+		}
+		p := h.PrimaryFunc()
+		if p == nil {
+			return nil, fmt.Errorf("itf: HAClient.PrimaryFunc returned no peer")
+		}
+		return p, nil
+	case Sticky:
+		h.mu.RLock()
+		idx := h.stickyIdx
+		h.mu.RUnlock()
+		if h.isReachable(idx) {
+			return h.Peers[idx], nil
+		}
+		for i := range h.Peers {
+			if h.isReachable(i) {
+				h.mu.Lock()
+				h.stickyIdx = i
+				h.mu.Unlock()
+				return h.Peers[i], nil
+			}
+		}
+		return nil, fmt.Errorf("itf: HAClient: no reachable peer")
+	default: // FirstReachable
+		for i := range h.Peers {
+			if h.isReachable(i) {
+				return h.Peers[i], nil
+			}
+		}
+		return nil, fmt.Errorf("itf: HAClient: no reachable peer")
+	}
+}
+
+// retryIdempotent calls fn against the primary peer first, then against
+// every other reachable peer in order, returning the first successful
+// result.
+func (h *HAClient) retryIdempotent(fn func(*Client) error) error {
+	primary, err := h.primary()
+	var lastErr error
+	tried := make(map[*Client]bool)
+	if err == nil {
+		tried[primary] = true
+		if lastErr = fn(primary); lastErr == nil {
+			return nil
+		}
+	} else {
+		lastErr = err
+	}
+	for i, p := range h.Peers {
+		if tried[p] || !h.isReachable(i) {
+			continue
+		}
+		tried[p] = true
+		if lastErr = fn(p); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// ListDevicesCtx retrieves the device descriptions from all devices,
+// retrying against a healthy peer on failure.
+func (h *HAClient) ListDevicesCtx(ctx context.Context) ([]*DeviceDescription, error) {
+	var res []*DeviceDescription
+	err := h.retryIdempotent(func(c *Client) error {
+		var err error
+		res, err = c.ListDevicesCtx(ctx)
+		return err
+	})
+	return res, err
+}
+
+// GetDeviceDescriptionCtx retrieves a device description, retrying against
+// a healthy peer on failure.
+func (h *HAClient) GetDeviceDescriptionCtx(ctx context.Context, deviceAddress string) (*DeviceDescription, error) {
+	var res *DeviceDescription
+	err := h.retryIdempotent(func(c *Client) error {
+		var err error
+		res, err = c.GetDeviceDescriptionCtx(ctx, deviceAddress)
+		return err
+	})
+	return res, err
+}
+
+// GetParamsetCtx retrieves a parameter set, retrying against a healthy peer
+// on failure.
+func (h *HAClient) GetParamsetCtx(ctx context.Context, deviceAddress, paramsetType string) (map[string]interface{}, error) {
+	var res map[string]interface{}
+	err := h.retryIdempotent(func(c *Client) error {
+		var err error
+		res, err = c.GetParamsetCtx(ctx, deviceAddress, paramsetType)
+		return err
+	})
+	return res, err
+}
+
+// GetValueCtx gets a single value, retrying against a healthy peer on
+// failure.
+func (h *HAClient) GetValueCtx(ctx context.Context, deviceAddress, valueName string) (interface{}, error) {
+	var res interface{}
+	err := h.retryIdempotent(func(c *Client) error {
+		var err error
+		res, err = c.GetValueCtx(ctx, deviceAddress, valueName)
+		return err
+	})
+	return res, err
+}
+
+// PingCtx succeeds as soon as the primary or any other peer marked reachable
+// answers.
+func (h *HAClient) PingCtx(ctx context.Context, callerID string) (bool, error) {
+	var res bool
+	err := h.retryIdempotent(func(c *Client) error {
+		var err error
+		res, err = c.PingCtx(ctx, callerID)
+		return err
+	})
+	return res, err
+}
+
+// SetValueCtx sets a single value on the current primary peer only.
+func (h *HAClient) SetValueCtx(ctx context.Context, deviceAddress, valueName string, value interface{}) error {
+	p, err := h.primary()
+	if err != nil {
+		return err
+	}
+	return p.SetValueCtx(ctx, deviceAddress, valueName, value)
+}
+
+// PutParamsetCtx writes a parameter set on the current primary peer only.
+func (h *HAClient) PutParamsetCtx(ctx context.Context, deviceAddress, paramsetType string, paramset map[string]interface{}) error {
+	p, err := h.primary()
+	if err != nil {
+		return err
+	}
+	return p.PutParamsetCtx(ctx, deviceAddress, paramsetType, paramset)
+}
+
+// InitCtx registers a new interface on the current primary peer only.
+func (h *HAClient) InitCtx(ctx context.Context, receiverAddress, id string) error {
+	p, err := h.primary()
+	if err != nil {
+		return err
+	}
+	return p.InitCtx(ctx, receiverAddress, id)
+}
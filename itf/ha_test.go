@@ -0,0 +1,105 @@
+package itf
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mdzio/go-hmccu/model"
+)
+
+func TestHAClientPrimaryFirstReachable(t *testing.T) {
+	peers := []*Client{{Addr: "a"}, {Addr: "b"}, {Addr: "c"}}
+	h := &HAClient{Peers: peers, reachable: []bool{false, true, true}}
+
+	p, err := h.primary()
+	if err != nil {
+		t.Fatalf("primary() returned error: %v", err)
+	}
+	if p != peers[1] {
+		t.Errorf("expected first reachable peer %q, got %q", peers[1].Addr, p.Addr)
+	}
+}
+
+func TestHAClientPrimaryNoneReachable(t *testing.T) {
+	peers := []*Client{{Addr: "a"}, {Addr: "b"}}
+	h := &HAClient{Peers: peers, reachable: []bool{false, false}}
+
+	if _, err := h.primary(); err == nil {
+		t.Error("expected an error when no peer is reachable")
+	}
+}
+
+func TestHAClientPrimarySticky(t *testing.T) {
+	peers := []*Client{{Addr: "a"}, {Addr: "b"}}
+	h := &HAClient{Peers: peers, Policy: Sticky, reachable: []bool{false, true}}
+
+	p, err := h.primary()
+	if err != nil {
+		t.Fatalf("primary() returned error: %v", err)
+	}
+	if p != peers[1] {
+		t.Fatalf("expected sticky primary %q, got %q", peers[1].Addr, p.Addr)
+	}
+
+	// peers[1] (the sticky primary) goes down while peers[0] recovers:
+	// Sticky must fail over, not get stuck on the now-unreachable peer.
+	h.reachable[0] = true
+	h.reachable[1] = false
+	p2, err := h.primary()
+	if err != nil {
+		t.Fatalf("primary() returned error: %v", err)
+	}
+	if p2 != peers[0] {
+		t.Fatalf("expected failover to %q, got %q", peers[0].Addr, p2.Addr)
+	}
+}
+
+func TestHAClientRetryIdempotentFailover(t *testing.T) {
+	resp, err := model.NewValue("42")
+	if err != nil {
+		t.Fatalf("building fake response: %v", err)
+	}
+	down := &fakeRPC{err: errors.New("connection refused")}
+	up := &fakeRPC{resp: resp}
+	peers := []*Client{
+		{Addr: "primary", rpcClient: down},
+		{Addr: "standby", rpcClient: up},
+	}
+	h := NewHAClient(peers...)
+	h.reachable[1] = true
+
+	val, err := h.GetValueCtx(context.Background(), "DEV1:1", "STATE")
+	if err != nil {
+		t.Fatalf("GetValueCtx returned error: %v", err)
+	}
+	if val != "42" {
+		t.Errorf("expected value from standby peer, got %v", val)
+	}
+	if down.gotMethod == "" {
+		t.Error("expected the down primary to be tried first")
+	}
+	if up.gotMethod != "getValue" {
+		t.Error("expected the standby peer to serve the retried call")
+	}
+}
+
+func TestHAClientSetValueRoutesToPrimaryOnly(t *testing.T) {
+	primary := &fakeRPC{resp: &model.Value{FlatString: ""}}
+	standby := &fakeRPC{resp: &model.Value{FlatString: ""}}
+	peers := []*Client{
+		{Addr: "primary", rpcClient: primary},
+		{Addr: "standby", rpcClient: standby},
+	}
+	h := NewHAClient(peers...)
+
+	if err := h.SetValueCtx(context.Background(), "DEV1:1", "STATE", true); err != nil {
+		t.Fatalf("SetValueCtx returned error: %v", err)
+	}
+	if primary.gotMethod != "setValue" {
+		t.Error("expected SetValueCtx to be routed to the primary peer")
+	}
+	if standby.gotMethod != "" {
+		t.Error("expected the standby peer to never see a state-changing call")
+	}
+}
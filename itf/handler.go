@@ -3,8 +3,8 @@ package itf
 import (
 	"fmt"
 
-	"github.com/mdzio/go-logging"
 	"github.com/mdzio/go-hmccu/xmlrpc"
+	"github.com/mdzio/go-logging"
 )
 
 var svrLog = logging.Get("itf-server")
@@ -40,157 +40,230 @@ type Receiver interface {
 type Handler struct {
 	xmlrpc.Handler
 	receiver Receiver
+	methods  map[string]func(*xmlrpc.Value) (*xmlrpc.Value, error)
 }
 
 // NewHandler creates a new HM XML-RPC handler.
 func NewHandler(receiver Receiver) *Handler {
 	h := &Handler{
 		receiver: receiver,
+		methods:  make(map[string]func(*xmlrpc.Value) (*xmlrpc.Value, error)),
 	}
 	h.SystemMethods()
 
-	h.HandleFunc("event", func(args *xmlrpc.Value) (*xmlrpc.Value, error) {
-		q := xmlrpc.Q(args)
-		if len(q.Slice()) != 4 {
-			return nil, fmt.Errorf("Expected 4 arguments for event method: %d", len(q.Slice()))
-		}
-		interfaceID := q.Idx(0).String()
-		address := q.Idx(1).String()
-		valueKey := q.Idx(2).String()
-		value := q.Idx(3).Any()
-		if q.Err() != nil {
-			return nil, fmt.Errorf("Invalid argument for event method: %v", q.Err())
-		}
-		svrLog.Debugf("Call of method event received: %s, %s, %s, %v", interfaceID, address, valueKey, value)
-		err := h.receiver.Event(interfaceID, address, valueKey, value)
-		if err != nil {
-			return nil, err
-		}
-		return &xmlrpc.Value{FlatString: ""}, nil
-	})
-
-	// attention: this implementation returns always an empty device list.
-	h.HandleFunc("listDevices", func(args *xmlrpc.Value) (*xmlrpc.Value, error) {
-		q := xmlrpc.Q(args)
-		if len(q.Slice()) != 1 {
-			return nil, fmt.Errorf("Expected one argument for listDevices method: %d", len(q.Slice()))
-		}
-		interfaceID := q.Idx(0).String()
-		if q.Err() != nil {
-			return nil, fmt.Errorf("Invalid argument for listDevices method: %v", q.Err())
+	h.handle("event", h.handleEvent)
+	h.handle("listDevices", h.handleListDevices)
+	h.handle("newDevices", h.handleNewDevices)
+	h.handle("deleteDevices", h.handleDeleteDevices)
+	h.handle("updateDevice", h.handleUpdateDevice)
+	h.handle("replaceDevice", h.handleReplaceDevice)
+	h.handle("readdedDevice", h.handleReaddedDevice)
+
+	h.HandleFunc("system.multicall", h.handleMulticall)
+
+	return h
+}
+
+// handle registers fn both as a regular XML-RPC method and in the internal
+// dispatch table used to serve system.multicall requests.
+func (h *Handler) handle(method string, fn func(*xmlrpc.Value) (*xmlrpc.Value, error)) {
+	h.methods[method] = fn
+	h.HandleFunc(method, fn)
+}
+
+// handleMulticall unpacks a system.multicall request (an array of
+// {methodName, params} structs) and dispatches each call to the matching
+// registered method. This is how the CCU bundles many event callbacks into
+// a single HTTP request. Per the XML-RPC multicall convention, the result
+// for each call is either a single-element array wrapping the method's
+// result, or a fault struct describing why the call failed.
+func (h *Handler) handleMulticall(args *xmlrpc.Value) (*xmlrpc.Value, error) {
+	q := xmlrpc.Q(args)
+	calls := q.Idx(0).Slice()
+	if q.Err() != nil {
+		return nil, fmt.Errorf("Invalid argument for system.multicall method: %v", q.Err())
+	}
+	svrLog.Debugf("Call of method system.multicall received: %d calls", len(calls))
+
+	results := make([]*xmlrpc.Value, len(calls))
+	for i, call := range calls {
+		members := call.Map()
+		if call.Err() != nil {
+			return nil, fmt.Errorf("Invalid call at index %d in system.multicall: %v", i, call.Err())
 		}
-		svrLog.Debugf("Call of method listDevices received: %s", interfaceID)
-		return &xmlrpc.Value{Array: &xmlrpc.Array{Data: []*xmlrpc.Value{}}}, nil
-	})
-
-	h.HandleFunc("newDevices", func(args *xmlrpc.Value) (*xmlrpc.Value, error) {
-		q := xmlrpc.Q(args)
-		if len(q.Slice()) != 2 {
-			return nil, fmt.Errorf("Expected 2 arguments for newDevices method: %d", len(q.Slice()))
+		methodNameValue, hasMethodName := members["methodName"]
+		params, hasParams := members["params"]
+		if !hasMethodName || !hasParams {
+			results[i] = faultValue(-1, fmt.Sprintf("call at index %d is missing methodName or params", i))
+			continue
 		}
-		interfaceID := q.Idx(0).String()
-		devDescriptions := q.Idx(1).Slice()
-		if q.Err() != nil {
-			return nil, fmt.Errorf("Invalid argument for newDevices method: %v", q.Err())
+		methodName := methodNameValue.String()
+		if methodNameValue.Err() != nil {
+			return nil, fmt.Errorf("Invalid methodName at index %d in system.multicall: %v", i, methodNameValue.Err())
 		}
-		svrLog.Debugf("Call of method newDevices received: %s, %d devices", interfaceID, len(devDescriptions))
-		var descr []*DeviceDescription
-		for _, q := range devDescriptions {
-			d := &DeviceDescription{}
-			d.ReadFrom(q)
-			if q.Err() != nil {
-				return nil, fmt.Errorf("Invalid device description for newDevices method: %v", q.Err())
-			}
-			descr = append(descr, d)
+		fn, ok := h.methods[methodName]
+		if !ok {
+			results[i] = faultValue(-1, fmt.Sprintf("unknown method %s", methodName))
+			continue
 		}
-		err := h.receiver.NewDevices(interfaceID, descr)
+		res, err := fn(params)
 		if err != nil {
-			return nil, err
+			results[i] = faultValue(-1, err.Error())
+			continue
 		}
-		return &xmlrpc.Value{FlatString: ""}, nil
-	})
+		results[i] = &xmlrpc.Value{Array: &xmlrpc.Array{Data: []*xmlrpc.Value{res}}}
+	}
+	return &xmlrpc.Value{Array: &xmlrpc.Array{Data: results}}, nil
+}
 
-	h.HandleFunc("deleteDevices", func(args *xmlrpc.Value) (*xmlrpc.Value, error) {
-		q := xmlrpc.Q(args)
-		if len(q.Slice()) != 2 {
-			return nil, fmt.Errorf("Expected 2 arguments for deleteDevices method: %d", len(q.Slice()))
-		}
-		interfaceID := q.Idx(0).String()
-		addressesValue := q.Idx(1).Slice()
-		var addresses []string
-		for _, addrValue := range addressesValue {
-			addresses = append(addresses, addrValue.String())
-		}
-		if q.Err() != nil {
-			return nil, fmt.Errorf("Invalid argument(s) for deleteDevices method: %v", q.Err())
-		}
-		svrLog.Debugf("Call of method deleteDevices received: %s, %v", interfaceID, addresses)
-		err := h.receiver.DeleteDevices(interfaceID, addresses)
-		if err != nil {
-			return nil, err
-		}
-		return &xmlrpc.Value{FlatString: ""}, nil
-	})
+// faultValue builds the XML-RPC fault struct used for a failed
+// system.multicall entry.
+func faultValue(code int, message string) *xmlrpc.Value {
+	return &xmlrpc.Value{Struct: &xmlrpc.Struct{Members: map[string]*xmlrpc.Value{
+		"faultCode":   &xmlrpc.Value{Int: code},
+		"faultString": &xmlrpc.Value{FlatString: message},
+	}}}
+}
 
-	h.HandleFunc("updateDevice", func(args *xmlrpc.Value) (*xmlrpc.Value, error) {
-		q := xmlrpc.Q(args)
-		if len(q.Slice()) != 3 {
-			return nil, fmt.Errorf("Expected 3 arguments for updateDevice method: %d", len(q.Slice()))
-		}
-		interfaceID := q.Idx(0).String()
-		address := q.Idx(1).String()
-		hint := q.Idx(2).Int()
-		if q.Err() != nil {
-			return nil, fmt.Errorf("Invalid argument(s) for updateDevice method: %v", q.Err())
-		}
-		svrLog.Debugf("Call of method updateDevice received: %s, %s, %d", interfaceID, address, hint)
-		err := h.receiver.UpdateDevice(interfaceID, address, hint)
-		if err != nil {
-			return nil, err
-		}
-		return &xmlrpc.Value{FlatString: ""}, nil
-	})
+func (h *Handler) handleEvent(args *xmlrpc.Value) (*xmlrpc.Value, error) {
+	q := xmlrpc.Q(args)
+	if len(q.Slice()) != 4 {
+		return nil, fmt.Errorf("Expected 4 arguments for event method: %d", len(q.Slice()))
+	}
+	interfaceID := q.Idx(0).String()
+	address := q.Idx(1).String()
+	valueKey := q.Idx(2).String()
+	value := q.Idx(3).Any()
+	if q.Err() != nil {
+		return nil, fmt.Errorf("Invalid argument for event method: %v", q.Err())
+	}
+	svrLog.Debugf("Call of method event received: %s, %s, %s, %v", interfaceID, address, valueKey, value)
+	err := h.receiver.Event(interfaceID, address, valueKey, value)
+	if err != nil {
+		return nil, err
+	}
+	return &xmlrpc.Value{FlatString: ""}, nil
+}
 
-	h.HandleFunc("replaceDevice", func(args *xmlrpc.Value) (*xmlrpc.Value, error) {
-		q := xmlrpc.Q(args)
-		if len(q.Slice()) != 3 {
-			return nil, fmt.Errorf("Expected 3 arguments for replaceDevice method: %d", len(q.Slice()))
-		}
-		interfaceID := q.Idx(0).String()
-		oldDeviceAddress := q.Idx(1).String()
-		newDeviceAddress := q.Idx(2).String()
-		if q.Err() != nil {
-			return nil, fmt.Errorf("Invalid argument(s) for replaceDevice method: %v", q.Err())
-		}
-		svrLog.Debugf("Call of method replaceDevice received: %s, %s, %s", interfaceID, oldDeviceAddress, newDeviceAddress)
-		err := h.receiver.ReplaceDevice(interfaceID, oldDeviceAddress, newDeviceAddress)
-		if err != nil {
-			return nil, err
-		}
-		return &xmlrpc.Value{FlatString: ""}, nil
-	})
+// attention: this implementation returns always an empty device list.
+func (h *Handler) handleListDevices(args *xmlrpc.Value) (*xmlrpc.Value, error) {
+	q := xmlrpc.Q(args)
+	if len(q.Slice()) != 1 {
+		return nil, fmt.Errorf("Expected one argument for listDevices method: %d", len(q.Slice()))
+	}
+	interfaceID := q.Idx(0).String()
+	if q.Err() != nil {
+		return nil, fmt.Errorf("Invalid argument for listDevices method: %v", q.Err())
+	}
+	svrLog.Debugf("Call of method listDevices received: %s", interfaceID)
+	return &xmlrpc.Value{Array: &xmlrpc.Array{Data: []*xmlrpc.Value{}}}, nil
+}
 
-	h.HandleFunc("readdedDevice", func(args *xmlrpc.Value) (*xmlrpc.Value, error) {
-		q := xmlrpc.Q(args)
-		if len(q.Slice()) != 2 {
-			return nil, fmt.Errorf("Expected 2 arguments for readdedDevice method: %d", len(q.Slice()))
-		}
-		interfaceID := q.Idx(0).String()
-		deletedAddresses := q.Idx(1).Slice()
-		var addresses []string
-		for _, addrValue := range deletedAddresses {
-			addresses = append(addresses, addrValue.String())
-		}
+func (h *Handler) handleNewDevices(args *xmlrpc.Value) (*xmlrpc.Value, error) {
+	q := xmlrpc.Q(args)
+	if len(q.Slice()) != 2 {
+		return nil, fmt.Errorf("Expected 2 arguments for newDevices method: %d", len(q.Slice()))
+	}
+	interfaceID := q.Idx(0).String()
+	devDescriptions := q.Idx(1).Slice()
+	if q.Err() != nil {
+		return nil, fmt.Errorf("Invalid argument for newDevices method: %v", q.Err())
+	}
+	svrLog.Debugf("Call of method newDevices received: %s, %d devices", interfaceID, len(devDescriptions))
+	var descr []*DeviceDescription
+	for _, q := range devDescriptions {
+		d := &DeviceDescription{}
+		d.ReadFrom(q)
 		if q.Err() != nil {
-			return nil, fmt.Errorf("Invalid argument(s) for readdedDevice method: %v", q.Err())
-		}
-		svrLog.Debugf("Call of method readdedDevice received: %s, %v", interfaceID, addresses)
-		err := h.receiver.ReaddedDevice(interfaceID, addresses)
-		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("Invalid device description for newDevices method: %v", q.Err())
 		}
-		return &xmlrpc.Value{FlatString: ""}, nil
-	})
+		descr = append(descr, d)
+	}
+	err := h.receiver.NewDevices(interfaceID, descr)
+	if err != nil {
+		return nil, err
+	}
+	return &xmlrpc.Value{FlatString: ""}, nil
+}
 
-	return h
+func (h *Handler) handleDeleteDevices(args *xmlrpc.Value) (*xmlrpc.Value, error) {
+	q := xmlrpc.Q(args)
+	if len(q.Slice()) != 2 {
+		return nil, fmt.Errorf("Expected 2 arguments for deleteDevices method: %d", len(q.Slice()))
+	}
+	interfaceID := q.Idx(0).String()
+	addressesValue := q.Idx(1).Slice()
+	var addresses []string
+	for _, addrValue := range addressesValue {
+		addresses = append(addresses, addrValue.String())
+	}
+	if q.Err() != nil {
+		return nil, fmt.Errorf("Invalid argument(s) for deleteDevices method: %v", q.Err())
+	}
+	svrLog.Debugf("Call of method deleteDevices received: %s, %v", interfaceID, addresses)
+	err := h.receiver.DeleteDevices(interfaceID, addresses)
+	if err != nil {
+		return nil, err
+	}
+	return &xmlrpc.Value{FlatString: ""}, nil
+}
+
+func (h *Handler) handleUpdateDevice(args *xmlrpc.Value) (*xmlrpc.Value, error) {
+	q := xmlrpc.Q(args)
+	if len(q.Slice()) != 3 {
+		return nil, fmt.Errorf("Expected 3 arguments for updateDevice method: %d", len(q.Slice()))
+	}
+	interfaceID := q.Idx(0).String()
+	address := q.Idx(1).String()
+	hint := q.Idx(2).Int()
+	if q.Err() != nil {
+		return nil, fmt.Errorf("Invalid argument(s) for updateDevice method: %v", q.Err())
+	}
+	svrLog.Debugf("Call of method updateDevice received: %s, %s, %d", interfaceID, address, hint)
+	err := h.receiver.UpdateDevice(interfaceID, address, hint)
+	if err != nil {
+		return nil, err
+	}
+	return &xmlrpc.Value{FlatString: ""}, nil
+}
+
+func (h *Handler) handleReplaceDevice(args *xmlrpc.Value) (*xmlrpc.Value, error) {
+	q := xmlrpc.Q(args)
+	if len(q.Slice()) != 3 {
+		return nil, fmt.Errorf("Expected 3 arguments for replaceDevice method: %d", len(q.Slice()))
+	}
+	interfaceID := q.Idx(0).String()
+	oldDeviceAddress := q.Idx(1).String()
+	newDeviceAddress := q.Idx(2).String()
+	if q.Err() != nil {
+		return nil, fmt.Errorf("Invalid argument(s) for replaceDevice method: %v", q.Err())
+	}
+	svrLog.Debugf("Call of method replaceDevice received: %s, %s, %s", interfaceID, oldDeviceAddress, newDeviceAddress)
+	err := h.receiver.ReplaceDevice(interfaceID, oldDeviceAddress, newDeviceAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &xmlrpc.Value{FlatString: ""}, nil
+}
+
+func (h *Handler) handleReaddedDevice(args *xmlrpc.Value) (*xmlrpc.Value, error) {
+	q := xmlrpc.Q(args)
+	if len(q.Slice()) != 2 {
+		return nil, fmt.Errorf("Expected 2 arguments for readdedDevice method: %d", len(q.Slice()))
+	}
+	interfaceID := q.Idx(0).String()
+	deletedAddresses := q.Idx(1).Slice()
+	var addresses []string
+	for _, addrValue := range deletedAddresses {
+		addresses = append(addresses, addrValue.String())
+	}
+	if q.Err() != nil {
+		return nil, fmt.Errorf("Invalid argument(s) for readdedDevice method: %v", q.Err())
+	}
+	svrLog.Debugf("Call of method readdedDevice received: %s, %v", interfaceID, addresses)
+	err := h.receiver.ReaddedDevice(interfaceID, addresses)
+	if err != nil {
+		return nil, err
+	}
+	return &xmlrpc.Value{FlatString: ""}, nil
 }
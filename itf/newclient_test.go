@@ -0,0 +1,36 @@
+package itf
+
+import (
+	"testing"
+
+	"github.com/mdzio/go-hmccu/binrpc"
+	"github.com/mdzio/go-hmccu/xmlrpc"
+)
+
+func TestNewClientSchemeSelectsTransport(t *testing.T) {
+	cases := []struct {
+		address  string
+		wantAddr string
+	}{
+		{"http://ccu:2001", "http://ccu:2001"},
+		{"https://ccu:2001", "https://ccu:2001"},
+		{"binrpc://ccu:2002", "ccu:2002"},
+		{"binrpcs://ccu:2002", "ccu:2002"},
+		{"ccu:2002", "ccu:2002"},
+	}
+	for _, c := range cases {
+		client := NewClient(c.address)
+		switch rc := client.rpcClient.(type) {
+		case *xmlrpc.Client:
+			if rc.Addr != c.wantAddr {
+				t.Errorf("%s: got xmlrpc.Client.Addr %q, want %q", c.address, rc.Addr, c.wantAddr)
+			}
+		case *binrpc.Client:
+			if rc.Addr != c.wantAddr {
+				t.Errorf("%s: got binrpc.Client.Addr %q, want %q (scheme must be stripped)", c.address, rc.Addr, c.wantAddr)
+			}
+		default:
+			t.Fatalf("%s: unexpected transport %T", c.address, rc)
+		}
+	}
+}
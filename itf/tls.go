@@ -0,0 +1,83 @@
+package itf
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// CertReloader keeps a certificate/key pair loaded from disk and reloads it
+// on demand, so a long-running receiver does not need to be restarted when
+// its certificate is renewed.
+type CertReloader struct {
+	certFile, keyFile string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// NewCertReloader loads certFile/keyFile and returns a CertReloader serving
+// them. Call Watch to reload automatically on SIGHUP.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the certificate/key pair from disk again. On error the
+// previously loaded certificate keeps being served.
+func (r *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading certificate %s/%s: %w", r.certFile, r.keyFile, err)
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.mu.Unlock()
+	return nil
+}
+
+// GetCertificate implements the tls.Config.GetCertificate hook.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Watch reloads the certificate/key pair whenever the process receives
+// SIGHUP. Reload errors are logged and otherwise ignored, so a bad
+// deployment of new cert material does not take the receiver down.
+func (r *CertReloader) Watch() {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	go func() {
+		for range sig {
+			if err := r.Reload(); err != nil {
+				svrLog.Errorf("Reloading TLS certificate failed: %v", err)
+			} else {
+				svrLog.Infof("TLS certificate reloaded from %s", r.certFile)
+			}
+		}
+	}()
+}
+
+// ListenAndServeTLS serves h on addr using the certificate served by
+// reloader, rejecting plaintext connections. It blocks until the server
+// stops or returns an error.
+func (h *Handler) ListenAndServeTLS(addr string, reloader *CertReloader) error {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: h,
+		TLSConfig: &tls.Config{
+			GetCertificate: reloader.GetCertificate,
+		},
+	}
+	// cert/key are supplied via TLSConfig.GetCertificate.
+	return srv.ListenAndServeTLS("", "")
+}
@@ -0,0 +1,344 @@
+package itf
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// paramsetTypes are the paramsets fetched for every device/channel the
+// Walker visits.
+var paramsetTypes = []string{"MASTER", "VALUES", "LINK"}
+
+// defaultRequestTimeout is the ctx deadline Walker puts on the RPC calls it
+// issues from a Receiver callback (NewDevices, UpdateDevice), which has no
+// caller context to propagate. The deadline interrupts a stalled CCU call
+// only on a transport implementing ctxRpcClient (see Client.CallCtx).
+const defaultRequestTimeout = 30 * time.Second
+
+// WalkedDevice is the cached, fully resolved state of one device or
+// channel, as assembled by Walker.
+type WalkedDevice struct {
+	Description *DeviceDescription                `json:"description"`
+	Paramsets   map[string]ParamsetDescription    `json:"paramsetDescriptions"`
+	Values      map[string]map[string]interface{} `json:"values"`
+	// Errors holds, per paramset type, the error returned while fetching
+	// it (e.g. because the device/channel does not support that paramset
+	// type). A paramset type missing from Paramsets/Values but present
+	// here was tolerated, not silently dropped.
+	Errors map[string]error `json:"-"`
+}
+
+// Walker performs a full recursive enumeration of a CCU (ListDevices,
+// followed by the paramset descriptions and paramsets of every device and
+// channel) and keeps the result warm in memory by subscribing to the
+// corresponding Receiver callbacks. Once Refresh has run, GetValue-style
+// lookups can be answered from the cache without an RPC round trip.
+//
+// Walker implements Receiver so it can be passed directly to itf.NewHandler,
+// or chained behind another Receiver.
+type Walker struct {
+	client *Client
+
+	// RequestTimeout is the ctx deadline for RPC calls issued from a
+	// Receiver callback (NewDevices, UpdateDevice), which has no caller
+	// context to propagate. Defaults to defaultRequestTimeout when zero.
+	RequestTimeout time.Duration
+
+	mu      sync.RWMutex
+	devices map[string]*WalkedDevice
+	// order preserves the ListDevices order for WalkDevices.
+	order []string
+}
+
+// NewWalker creates a Walker that resolves devices through client. Call
+// Refresh once before use to populate the cache.
+func NewWalker(client *Client) *Walker {
+	return &Walker{
+		client:  client,
+		devices: make(map[string]*WalkedDevice),
+	}
+}
+
+// Refresh re-enumerates the whole CCU and replaces the current cache with
+// the result. On error the current cache is left unchanged.
+func (w *Walker) Refresh(ctx context.Context) error {
+	descrs, err := w.client.ListDevicesCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	devices := make(map[string]*WalkedDevice, len(descrs))
+	order := make([]string, 0, len(descrs))
+	for _, d := range descrs {
+		wd, err := w.load(ctx, d)
+		if err != nil {
+			return err
+		}
+		devices[d.Address] = wd
+		order = append(order, d.Address)
+	}
+
+	w.mu.Lock()
+	w.devices = devices
+	w.order = order
+	w.mu.Unlock()
+	return nil
+}
+
+// requestTimeout returns w.RequestTimeout, or defaultRequestTimeout if unset.
+func (w *Walker) requestTimeout() time.Duration {
+	if w.RequestTimeout > 0 {
+		return w.RequestTimeout
+	}
+	return defaultRequestTimeout
+}
+
+// isFatal reports whether err means the call was aborted (ctx done) rather
+// than the CCU simply not supporting the requested paramset type. Fatal
+// errors must propagate instead of being tolerated.
+func isFatal(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// load fetches the paramset descriptions and paramsets for one device or
+// channel description. Not every device/channel supports every paramset
+// type; that is tolerated and recorded in WalkedDevice.Errors. A ctx
+// cancellation/deadline is not tolerated and is returned as-is, since it
+// means the walk was aborted, not that a paramset type is unsupported.
+func (w *Walker) load(ctx context.Context, d *DeviceDescription) (*WalkedDevice, error) {
+	wd := &WalkedDevice{
+		Description: d,
+		Paramsets:   make(map[string]ParamsetDescription),
+		Values:      make(map[string]map[string]interface{}),
+		Errors:      make(map[string]error),
+	}
+	for _, pt := range paramsetTypes {
+		pd, err := w.client.GetParamsetDescriptionCtx(ctx, d.Address, pt)
+		if err != nil {
+			if isFatal(err) {
+				return nil, err
+			}
+			wd.Errors[pt] = err
+			continue
+		}
+		wd.Paramsets[pt] = pd
+
+		values, err := w.client.GetParamsetCtx(ctx, d.Address, pt)
+		if err != nil {
+			if isFatal(err) {
+				return nil, err
+			}
+			wd.Errors[pt] = err
+			continue
+		}
+		wd.Values[pt] = values
+	}
+	return wd, nil
+}
+
+// WalkDevices returns the device descriptions of all top-level devices, in
+// ListDevices order.
+func (w *Walker) WalkDevices() []*DeviceDescription {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	var res []*DeviceDescription
+	for _, addr := range w.order {
+		if d := w.devices[addr]; d != nil && d.Description.Parent == "" {
+			res = append(res, d.Description)
+		}
+	}
+	return res
+}
+
+// WalkChannels returns the channel descriptions of the device at
+// deviceAddr, in the order listed in its Children field.
+func (w *Walker) WalkChannels(deviceAddr string) []*DeviceDescription {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	d, ok := w.devices[deviceAddr]
+	if !ok {
+		return nil
+	}
+	var res []*DeviceDescription
+	for _, addr := range d.Description.Children {
+		if c, ok := w.devices[addr]; ok {
+			res = append(res, c.Description)
+		}
+	}
+	return res
+}
+
+// WalkParameters returns a copy of the cached values of paramset on
+// channelAddr. A copy is returned, not the live cache map, since Event
+// keeps updating the cache after this call returns.
+func (w *Walker) WalkParameters(channelAddr, paramset string) map[string]interface{} {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	d, ok := w.devices[channelAddr]
+	if !ok {
+		return nil
+	}
+	return copyParamsetValues(d.Values[paramset])
+}
+
+// Snapshot returns a JSON-serialisable dump of the whole cache, keyed by
+// device/channel address. Each WalkedDevice's Values are copied, since Event
+// keeps updating the cache after this call returns.
+func (w *Walker) Snapshot() map[string]*WalkedDevice {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	snap := make(map[string]*WalkedDevice, len(w.devices))
+	for addr, d := range w.devices {
+		cp := *d
+		cp.Values = copyValues(d.Values)
+		snap[addr] = &cp
+	}
+	return snap
+}
+
+// copyParamsetValues returns a shallow copy of one paramset's cached values.
+func copyParamsetValues(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	cp := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		cp[k] = v
+	}
+	return cp
+}
+
+// copyValues returns a shallow copy of a WalkedDevice's Values, including a
+// copy of each per-paramset sub-map.
+func copyValues(values map[string]map[string]interface{}) map[string]map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	cp := make(map[string]map[string]interface{}, len(values))
+	for paramset, vals := range values {
+		cp[paramset] = copyParamsetValues(vals)
+	}
+	return cp
+}
+
+// Event implements Receiver by updating the cached value. The per-paramset
+// map is replaced wholesale rather than mutated in place, so a copy returned
+// by WalkParameters/Snapshot before this call is never touched by it.
+func (w *Walker) Event(interfaceID, address, valueKey string, value interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	d, ok := w.devices[address]
+	if !ok {
+		return nil
+	}
+	values := copyParamsetValues(d.Values["VALUES"])
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	values[valueKey] = value
+	d.Values["VALUES"] = values
+	return nil
+}
+
+// NewDevices implements Receiver by fetching and caching the paramsets of
+// the newly added devices. Each fetch runs under a RequestTimeout deadline,
+// which interrupts a stalled CCU call only on a transport implementing
+// ctxRpcClient (see Client.CallCtx).
+func (w *Walker) NewDevices(interfaceID string, devDescriptions []*DeviceDescription) error {
+	for _, d := range devDescriptions {
+		ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+		wd, err := w.load(ctx, d)
+		cancel()
+		if err != nil {
+			return err
+		}
+		w.mu.Lock()
+		if _, exists := w.devices[d.Address]; !exists {
+			w.order = append(w.order, d.Address)
+		}
+		w.devices[d.Address] = wd
+		w.mu.Unlock()
+	}
+	return nil
+}
+
+// DeleteDevices implements Receiver by dropping the given addresses from
+// the cache.
+func (w *Walker) DeleteDevices(interfaceID string, addresses []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, addr := range addresses {
+		delete(w.devices, addr)
+	}
+	w.order = removeAll(w.order, addresses)
+	return nil
+}
+
+// UpdateDevice implements Receiver by re-fetching the paramsets of the
+// changed device. The fetch runs under a RequestTimeout deadline, which
+// interrupts a stalled CCU call only on a transport implementing
+// ctxRpcClient (see Client.CallCtx). If address was concurrently
+// removed (DeleteDevices/ReaddedDevice) while the refetch was in flight,
+// the stale result is discarded instead of resurrecting the device.
+func (w *Walker) UpdateDevice(interfaceID, address string, hint int) error {
+	w.mu.RLock()
+	d, ok := w.devices[address]
+	w.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	wd, err := w.load(ctx, d.Description)
+	cancel()
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	if _, stillPresent := w.devices[address]; stillPresent {
+		w.devices[address] = wd
+	}
+	w.mu.Unlock()
+	return nil
+}
+
+// ReplaceDevice implements Receiver by moving the cached entry to the new
+// address.
+func (w *Walker) ReplaceDevice(interfaceID, oldDeviceAddress, newDeviceAddress string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	d, ok := w.devices[oldDeviceAddress]
+	if !ok {
+		return nil
+	}
+	delete(w.devices, oldDeviceAddress)
+	w.devices[newDeviceAddress] = d
+	for i, addr := range w.order {
+		if addr == oldDeviceAddress {
+			w.order[i] = newDeviceAddress
+		}
+	}
+	return nil
+}
+
+// ReaddedDevice implements Receiver by dropping the stale addresses; the
+// CCU is expected to send NewDevices for the re-paired device afterwards.
+func (w *Walker) ReaddedDevice(interfaceID string, deletedAddresses []string) error {
+	return w.DeleteDevices(interfaceID, deletedAddresses)
+}
+
+// removeAll returns order with every address in remove filtered out.
+func removeAll(order []string, remove []string) []string {
+	drop := make(map[string]bool, len(remove))
+	for _, addr := range remove {
+		drop[addr] = true
+	}
+	res := order[:0]
+	for _, addr := range order {
+		if !drop[addr] {
+			res = append(res, addr)
+		}
+	}
+	return res
+}
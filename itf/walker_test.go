@@ -0,0 +1,118 @@
+package itf
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mdzio/go-hmccu/model"
+)
+
+// racyRPC is a minimal rpcClient whose Call always fails, optionally
+// running onCall first so a test can inject a concurrent cache mutation
+// while the "RPC round trip" is in flight.
+type racyRPC struct {
+	onCall func()
+	err    error
+}
+
+func (r *racyRPC) Call(method string, params []*model.Value) (*model.Value, error) {
+	if r.onCall != nil {
+		r.onCall()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return nil, errors.New("paramset type not supported")
+}
+
+func newTestWalker(rc rpcClient) *Walker {
+	return NewWalker(&Client{Addr: "test", rpcClient: rc})
+}
+
+func TestWalkerEventUpdatesCache(t *testing.T) {
+	w := newTestWalker(&racyRPC{})
+	w.devices["DEV1:1"] = &WalkedDevice{
+		Description: &DeviceDescription{Address: "DEV1:1"},
+		Values:      make(map[string]map[string]interface{}),
+	}
+
+	if err := w.Event("itf1", "DEV1:1", "STATE", true); err != nil {
+		t.Fatalf("Event returned error: %v", err)
+	}
+	if got := w.devices["DEV1:1"].Values["VALUES"]["STATE"]; got != true {
+		t.Errorf("expected cached STATE value true, got %v", got)
+	}
+}
+
+func TestWalkerDeleteDevicesKeepsOrderConsistent(t *testing.T) {
+	w := newTestWalker(&racyRPC{})
+	w.devices["DEV1"] = &WalkedDevice{Description: &DeviceDescription{Address: "DEV1"}}
+	w.devices["DEV2"] = &WalkedDevice{Description: &DeviceDescription{Address: "DEV2"}}
+	w.order = []string{"DEV1", "DEV2"}
+
+	if err := w.DeleteDevices("itf1", []string{"DEV1"}); err != nil {
+		t.Fatalf("DeleteDevices returned error: %v", err)
+	}
+	if _, ok := w.devices["DEV1"]; ok {
+		t.Error("DEV1 should have been removed from devices")
+	}
+	for _, addr := range w.order {
+		if addr == "DEV1" {
+			t.Error("DEV1 should have been removed from order")
+		}
+	}
+}
+
+func TestWalkerLoadPropagatesCanceledContext(t *testing.T) {
+	w := newTestWalker(&racyRPC{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := w.load(ctx, &DeviceDescription{Address: "DEV1"})
+	if err == nil {
+		t.Fatal("expected a canceled context to propagate as an error")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestWalkerLoadTreatsOtherErrorsAsUnsupportedParamset(t *testing.T) {
+	w := newTestWalker(&racyRPC{})
+
+	wd, err := w.load(context.Background(), &DeviceDescription{Address: "DEV1"})
+	if err != nil {
+		t.Fatalf("load returned error: %v", err)
+	}
+	for _, pt := range paramsetTypes {
+		if wd.Errors[pt] == nil {
+			t.Errorf("expected %s fetch error to be recorded, got nil", pt)
+		}
+		if _, ok := wd.Paramsets[pt]; ok {
+			t.Errorf("did not expect %s to be cached after a failed fetch", pt)
+		}
+	}
+}
+
+func TestWalkerUpdateDeviceDiscardsResultOfConcurrentlyDeletedDevice(t *testing.T) {
+	const addr = "DEV1"
+	w := newTestWalker(&racyRPC{})
+	w.devices[addr] = &WalkedDevice{Description: &DeviceDescription{Address: addr}}
+	w.order = []string{addr}
+
+	// Simulate another goroutine's DeleteDevices landing while
+	// UpdateDevice's refetch is in flight.
+	w.client.rpcClient = &racyRPC{onCall: func() {
+		if err := w.DeleteDevices("itf1", []string{addr}); err != nil {
+			t.Fatalf("DeleteDevices returned error: %v", err)
+		}
+	}}
+
+	if err := w.UpdateDevice("itf1", addr, 0); err != nil {
+		t.Fatalf("UpdateDevice returned error: %v", err)
+	}
+	if _, ok := w.devices[addr]; ok {
+		t.Error("UpdateDevice must not resurrect a concurrently deleted device")
+	}
+}
@@ -0,0 +1,52 @@
+package itfhttp
+
+import "testing"
+
+func TestCoerceValue(t *testing.T) {
+	cases := []struct {
+		name      string
+		value     interface{}
+		paramType string
+		want      interface{}
+	}{
+		{"integer", float64(42), "INTEGER", 42},
+		{"enum", float64(2), "ENUM", 2},
+		{"float", float64(1.5), "FLOAT", 1.5},
+		{"double", float64(1.5), "DOUBLE", 1.5},
+		{"bool", true, "BOOL", true},
+		{"action", false, "ACTION", false},
+		{"string", "hello", "STRING", "hello"},
+		{"unknown type passthrough", "anything", "SOMETHING_ELSE", "anything"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := coerceValue(c.value, c.paramType)
+			if err != nil {
+				t.Fatalf("coerceValue returned error: %v", err)
+			}
+			if got != c.want {
+				t.Errorf("coerceValue(%v, %s) = %v, want %v", c.value, c.paramType, got, c.want)
+			}
+		})
+	}
+}
+
+func TestCoerceValueRejectsMismatchedJSONType(t *testing.T) {
+	cases := []struct {
+		name      string
+		value     interface{}
+		paramType string
+	}{
+		{"string for integer", "42", "INTEGER"},
+		{"bool for float", true, "FLOAT"},
+		{"number for bool", float64(1), "BOOL"},
+		{"number for string", float64(1), "STRING"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := coerceValue(c.value, c.paramType); err == nil {
+				t.Errorf("expected coerceValue(%v, %s) to reject a mismatched JSON type", c.value, c.paramType)
+			}
+		})
+	}
+}
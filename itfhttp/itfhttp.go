@@ -0,0 +1,256 @@
+// Package itfhttp exposes an itf.Client and the events forwarded to an
+// itf.Handler as a small REST/JSON API, so integrators that don't speak
+// XML-RPC or BinRPC (Node-RED, Home Assistant, browser tooling) can drive
+// a CCU over plain HTTP.
+//
+// Routes:
+//
+//	GET  /devices                               all device descriptions
+//	GET  /devices/{address}                      one device description
+//	GET  /devices/{address}/paramsets/{type}     a paramset (MASTER, VALUES, ...)
+//	PUT  /devices/{address}/values/{name}        set a single value
+//	POST /subscriptions                          SSE stream of Event callbacks
+package itfhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mdzio/go-hmccu/itf"
+	"github.com/mdzio/go-logging"
+)
+
+var log = logging.Get("itf-http")
+
+// Server serves the REST/JSON gateway. It implements itf.Receiver so it can
+// be passed to itf.NewHandler directly; Event callbacks received this way
+// are fanned out to all active /subscriptions streams.
+type Server struct {
+	Client *itf.Client
+
+	mu   sync.Mutex
+	subs map[chan event]struct{}
+}
+
+type event struct {
+	InterfaceID string      `json:"interfaceId"`
+	Address     string      `json:"address"`
+	ValueKey    string      `json:"valueKey"`
+	Value       interface{} `json:"value"`
+}
+
+// NewServer creates a gateway serving calls through client.
+func NewServer(client *itf.Client) *Server {
+	return &Server{
+		Client: client,
+		subs:   make(map[chan event]struct{}),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	segs := strings.Split(path, "/")
+
+	switch {
+	case len(segs) == 1 && segs[0] == "devices" && r.Method == http.MethodGet:
+		s.listDevices(w, r)
+	case len(segs) == 2 && segs[0] == "devices" && r.Method == http.MethodGet:
+		s.getDevice(w, r, segs[1])
+	case len(segs) == 4 && segs[0] == "devices" && segs[2] == "paramsets" && r.Method == http.MethodGet:
+		s.getParamset(w, r, segs[1], segs[3])
+	case len(segs) == 4 && segs[0] == "devices" && segs[2] == "values" && r.Method == http.MethodPut:
+		s.setValue(w, r, segs[1], segs[3])
+	case len(segs) == 1 && segs[0] == "subscriptions" && r.Method == http.MethodPost:
+		s.subscribe(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) listDevices(w http.ResponseWriter, r *http.Request) {
+	devs, err := s.Client.ListDevicesCtx(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, devs)
+}
+
+func (s *Server) getDevice(w http.ResponseWriter, r *http.Request, address string) {
+	dev, err := s.Client.GetDeviceDescriptionCtx(r.Context(), address)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, dev)
+}
+
+func (s *Server) getParamset(w http.ResponseWriter, r *http.Request, address, paramsetType string) {
+	ps, err := s.Client.GetParamsetCtx(r.Context(), address, paramsetType)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, ps)
+}
+
+func (s *Server) setValue(w http.ResponseWriter, r *http.Request, address, name string) {
+	var value interface{}
+	if err := json.NewDecoder(r.Body).Decode(&value); err != nil {
+		http.Error(w, fmt.Sprintf("invalid JSON body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// encoding/json always decodes a JSON number into float64; coerce it to
+	// the datapoint's declared type (e.g. INTEGER/ENUM) before forwarding
+	// it, otherwise an integer-typed parameter would be written as a double.
+	if pd, err := s.Client.GetParamsetDescriptionCtx(r.Context(), address, "VALUES"); err == nil {
+		if paramDescr, ok := pd[name]; ok {
+			coerced, err := coerceValue(value, paramDescr.Type)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			value = coerced
+		}
+	}
+
+	if err := s.Client.SetValueCtx(r.Context(), address, name, value); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// coerceValue converts a value JSON-decoded into a bare interface{} (which
+// always turns a JSON number into float64) into the Go type SetValueCtx
+// expects for paramType, as declared in a ParameterDescription.Type.
+func coerceValue(value interface{}, paramType string) (interface{}, error) {
+	switch paramType {
+	case "INTEGER", "ENUM":
+		f, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("value must be a JSON number for paramset type %s", paramType)
+		}
+		return int(f), nil
+	case "FLOAT", "DOUBLE":
+		f, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("value must be a JSON number for paramset type %s", paramType)
+		}
+		return f, nil
+	case "BOOL", "ACTION":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("value must be a JSON bool for paramset type %s", paramType)
+		}
+		return b, nil
+	case "STRING":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value must be a JSON string for paramset type %s", paramType)
+		}
+		return s, nil
+	default:
+		// unknown paramset type: forward the value unchanged
+		return value, nil
+	}
+}
+
+// subscribe streams Event callbacks as Server-Sent Events until the client
+// disconnects.
+func (s *Server) subscribe(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan event, 16)
+	s.mu.Lock()
+	s.subs[ch] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subs, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-ch:
+			data, err := json.Marshal(ev)
+			if err != nil {
+				log.Errorf("Marshalling event for subscription failed: %v", err)
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("Encoding JSON response failed: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	http.Error(w, err.Error(), http.StatusBadGateway)
+}
+
+// Event implements itf.Receiver. It fans the event out to all active
+// /subscriptions streams; it never returns an error.
+func (s *Server) Event(interfaceID, address, valueKey string, value interface{}) error {
+	ev := event{InterfaceID: interfaceID, Address: address, ValueKey: valueKey, Value: value}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subs {
+		select {
+		case ch <- ev:
+		default:
+			log.Warningf("Subscription channel full, dropping event for %s/%s", address, valueKey)
+		}
+	}
+	return nil
+}
+
+// NewDevices implements itf.Receiver. The gateway does not maintain its own
+// device cache; it always reads through to the Client.
+func (s *Server) NewDevices(interfaceID string, devDescriptions []*itf.DeviceDescription) error {
+	return nil
+}
+
+// DeleteDevices implements itf.Receiver.
+func (s *Server) DeleteDevices(interfaceID string, addresses []string) error {
+	return nil
+}
+
+// UpdateDevice implements itf.Receiver.
+func (s *Server) UpdateDevice(interfaceID, address string, hint int) error {
+	return nil
+}
+
+// ReplaceDevice implements itf.Receiver.
+func (s *Server) ReplaceDevice(interfaceID, oldDeviceAddress, newDeviceAddress string) error {
+	return nil
+}
+
+// ReaddedDevice implements itf.Receiver.
+func (s *Server) ReaddedDevice(interfaceID string, deletedAddresses []string) error {
+	return nil
+}
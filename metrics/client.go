@@ -0,0 +1,164 @@
+// Package metrics provides optional Prometheus instrumentation for
+// itf.Client and itf.Handler. It is kept in its own package so that
+// callers who do not need metrics don't have to pull in
+// prometheus/client_golang.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/mdzio/go-hmccu/itf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Client wraps an itf.Client and records Prometheus metrics for every RPC
+// call. Create one with WrapClient.
+type Client struct {
+	*itf.Client
+	addr string
+	cs   *collectorSet
+}
+
+// WrapClient instruments c with Prometheus metrics and registers the
+// collectors on reg. The returned Client can be used as a drop-in
+// replacement for c. Two Clients wrapped with different reg values get
+// fully independent metrics; wrapping several Clients with the same reg
+// (e.g. alongside WrapHandler) shares one set of collectors, as expected
+// for one registry.
+func WrapClient(c *itf.Client, reg prometheus.Registerer) *Client {
+	return &Client{Client: c, addr: c.Addr, cs: collectorsFor(reg)}
+}
+
+func (c *Client) observe(method string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	c.cs.rpcCallsTotal.WithLabelValues(method, c.addr, result).Inc()
+	c.cs.rpcDuration.WithLabelValues(method, c.addr).Observe(time.Since(start).Seconds())
+}
+
+func (c *Client) GetDeviceDescription(deviceAddress string) (*itf.DeviceDescription, error) {
+	return c.GetDeviceDescriptionCtx(context.Background(), deviceAddress)
+}
+
+func (c *Client) GetDeviceDescriptionCtx(ctx context.Context, deviceAddress string) (*itf.DeviceDescription, error) {
+	start := time.Now()
+	d, err := c.Client.GetDeviceDescriptionCtx(ctx, deviceAddress)
+	c.observe("getDeviceDescription", start, err)
+	return d, err
+}
+
+func (c *Client) ListDevices() ([]*itf.DeviceDescription, error) {
+	return c.ListDevicesCtx(context.Background())
+}
+
+func (c *Client) ListDevicesCtx(ctx context.Context) ([]*itf.DeviceDescription, error) {
+	start := time.Now()
+	d, err := c.Client.ListDevicesCtx(ctx)
+	c.observe("listDevices", start, err)
+	return d, err
+}
+
+func (c *Client) GetParamsetDescription(deviceAddress, paramsetType string) (itf.ParamsetDescription, error) {
+	return c.GetParamsetDescriptionCtx(context.Background(), deviceAddress, paramsetType)
+}
+
+func (c *Client) GetParamsetDescriptionCtx(ctx context.Context, deviceAddress, paramsetType string) (itf.ParamsetDescription, error) {
+	start := time.Now()
+	d, err := c.Client.GetParamsetDescriptionCtx(ctx, deviceAddress, paramsetType)
+	c.observe("getParamsetDescription", start, err)
+	return d, err
+}
+
+func (c *Client) GetParamset(deviceAddress, paramsetType string) (map[string]interface{}, error) {
+	return c.GetParamsetCtx(context.Background(), deviceAddress, paramsetType)
+}
+
+func (c *Client) GetParamsetCtx(ctx context.Context, deviceAddress, paramsetType string) (map[string]interface{}, error) {
+	start := time.Now()
+	d, err := c.Client.GetParamsetCtx(ctx, deviceAddress, paramsetType)
+	c.observe("getParamset", start, err)
+	return d, err
+}
+
+func (c *Client) PutParamset(deviceAddress, paramsetType string, paramset map[string]interface{}) error {
+	return c.PutParamsetCtx(context.Background(), deviceAddress, paramsetType, paramset)
+}
+
+func (c *Client) PutParamsetCtx(ctx context.Context, deviceAddress, paramsetType string, paramset map[string]interface{}) error {
+	start := time.Now()
+	err := c.Client.PutParamsetCtx(ctx, deviceAddress, paramsetType, paramset)
+	c.observe("putParamset", start, err)
+	return err
+}
+
+func (c *Client) SetValue(deviceAddress, valueName string, value interface{}) error {
+	return c.SetValueCtx(context.Background(), deviceAddress, valueName, value)
+}
+
+func (c *Client) SetValueCtx(ctx context.Context, deviceAddress, valueName string, value interface{}) error {
+	start := time.Now()
+	err := c.Client.SetValueCtx(ctx, deviceAddress, valueName, value)
+	c.observe("setValue", start, err)
+	return err
+}
+
+func (c *Client) GetValue(deviceAddress, valueName string) (interface{}, error) {
+	return c.GetValueCtx(context.Background(), deviceAddress, valueName)
+}
+
+func (c *Client) GetValueCtx(ctx context.Context, deviceAddress, valueName string) (interface{}, error) {
+	start := time.Now()
+	v, err := c.Client.GetValueCtx(ctx, deviceAddress, valueName)
+	c.observe("getValue", start, err)
+	return v, err
+}
+
+func (c *Client) Init(receiverAddress, id string) error {
+	return c.InitCtx(context.Background(), receiverAddress, id)
+}
+
+func (c *Client) InitCtx(ctx context.Context, receiverAddress, id string) error {
+	start := time.Now()
+	err := c.Client.InitCtx(ctx, receiverAddress, id)
+	c.observe("init", start, err)
+	if err == nil {
+		c.cs.interfaceStartTime.WithLabelValues(id).Set(float64(start.Unix()))
+	}
+	return err
+}
+
+func (c *Client) Deinit(receiverAddress string) error {
+	return c.DeinitCtx(context.Background(), receiverAddress)
+}
+
+func (c *Client) DeinitCtx(ctx context.Context, receiverAddress string) error {
+	start := time.Now()
+	err := c.Client.DeinitCtx(ctx, receiverAddress)
+	c.observe("deinit", start, err)
+	return err
+}
+
+func (c *Client) Ping(callerID string) (bool, error) {
+	return c.PingCtx(context.Background(), callerID)
+}
+
+func (c *Client) PingCtx(ctx context.Context, callerID string) (bool, error) {
+	start := time.Now()
+	ok, err := c.Client.PingCtx(ctx, callerID)
+	c.observe("ping", start, err)
+	return ok, err
+}
+
+func (c *Client) Event(interfaceID, address, valueKey string, value interface{}) error {
+	return c.EventCtx(context.Background(), interfaceID, address, valueKey, value)
+}
+
+func (c *Client) EventCtx(ctx context.Context, interfaceID, address, valueKey string, value interface{}) error {
+	start := time.Now()
+	err := c.Client.EventCtx(ctx, interfaceID, address, valueKey, value)
+	c.observe("event", start, err)
+	return err
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"github.com/mdzio/go-hmccu/itf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// receiver wraps an itf.Receiver and records Prometheus metrics for the
+// notifications forwarded to it.
+type receiver struct {
+	itf.Receiver
+	cs *collectorSet
+}
+
+// WrapHandler instruments r with Prometheus metrics and registers the
+// collectors on reg. Pass the result to itf.NewHandler in place of r:
+//
+//	h := itf.NewHandler(metrics.WrapHandler(r, reg))
+//
+// Two receivers wrapped with different reg values get fully independent
+// metrics, so separately instrumented CCUs don't bleed into each other's
+// counters.
+func WrapHandler(r itf.Receiver, reg prometheus.Registerer) itf.Receiver {
+	return &receiver{Receiver: r, cs: collectorsFor(reg)}
+}
+
+func (w *receiver) Event(interfaceID, address, valueKey string, value interface{}) error {
+	w.cs.eventsReceivedTotal.WithLabelValues(interfaceID).Inc()
+	return w.Receiver.Event(interfaceID, address, valueKey, value)
+}
+
+func (w *receiver) NewDevices(interfaceID string, devDescriptions []*itf.DeviceDescription) error {
+	err := w.Receiver.NewDevices(interfaceID, devDescriptions)
+	if err == nil {
+		w.cs.devicesKnown.Add(float64(len(devDescriptions)))
+	}
+	return err
+}
+
+func (w *receiver) DeleteDevices(interfaceID string, addresses []string) error {
+	err := w.Receiver.DeleteDevices(interfaceID, addresses)
+	if err == nil {
+		w.cs.devicesKnown.Sub(float64(len(addresses)))
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectorSet holds one independent instance of every collector
+// WrapClient/WrapHandler use, scoped to a single prometheus.Registerer.
+// Package-level collector variables would be shared and merged across
+// every caller's registry; collectorsFor keeps separately registered CCUs
+// (each on its own *prometheus.Registry) isolated from one another.
+type collectorSet struct {
+	rpcCallsTotal       *prometheus.CounterVec
+	rpcDuration         *prometheus.HistogramVec
+	interfaceStartTime  *prometheus.GaugeVec
+	eventsReceivedTotal *prometheus.CounterVec
+	devicesKnown        prometheus.Gauge
+}
+
+var (
+	setsMu sync.Mutex
+	sets   = map[prometheus.Registerer]*collectorSet{}
+)
+
+// collectorsFor returns the collectorSet registered on reg, creating and
+// registering one on first use. Calling it again with the same reg (e.g.
+// from both WrapClient and WrapHandler) returns the same set instead of
+// registering duplicate collectors.
+func collectorsFor(reg prometheus.Registerer) *collectorSet {
+	setsMu.Lock()
+	defer setsMu.Unlock()
+	if cs, ok := sets[reg]; ok {
+		return cs
+	}
+
+	cs := &collectorSet{
+		rpcCallsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hmccu_rpc_calls_total",
+			Help: "Total number of RPC calls issued to a CCU interface process.",
+		}, []string{"method", "addr", "result"}),
+
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "hmccu_rpc_duration_seconds",
+			Help:    "Duration of RPC calls issued to a CCU interface process.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "addr"}),
+
+		interfaceStartTime: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "hmccu_interface_start_time_seconds",
+			Help: "Unix timestamp of the last successful Init call for an interface.",
+		}, []string{"interface_id"}),
+
+		eventsReceivedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hmccu_events_received_total",
+			Help: "Total number of Event callbacks received from a CCU interface process.",
+		}, []string{"interface_id"}),
+
+		devicesKnown: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "hmccu_devices_known",
+			Help: "Number of devices currently known across all interfaces.",
+		}),
+	}
+	reg.MustRegister(cs.rpcCallsTotal, cs.rpcDuration, cs.interfaceStartTime, cs.eventsReceivedTotal, cs.devicesKnown)
+	sets[reg] = cs
+	return cs
+}
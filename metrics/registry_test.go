@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestCollectorsForReusesSetPerRegisterer(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	cs1 := collectorsFor(reg)
+	cs2 := collectorsFor(reg)
+	if cs1 != cs2 {
+		t.Error("expected collectorsFor to return the same collectorSet for the same Registerer")
+	}
+}
+
+func TestCollectorsForIsolatesDifferentRegisterers(t *testing.T) {
+	regA := prometheus.NewRegistry()
+	regB := prometheus.NewRegistry()
+
+	csA := collectorsFor(regA)
+	csB := collectorsFor(regB)
+	if csA == csB {
+		t.Error("expected independent collectorSets for independent Registerers")
+	}
+
+	csA.devicesKnown.Set(3)
+	if v := gaugeValue(t, csB.devicesKnown); v != 0 {
+		t.Errorf("expected regB's devicesKnown to be unaffected by regA, got %v", v)
+	}
+}
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := g.Write(&m); err != nil {
+		t.Fatalf("reading gauge value: %v", err)
+	}
+	return m.GetGauge().GetValue()
+}